@@ -2,56 +2,101 @@ package main
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bufio"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/cavaliercoder/grab"
-	"github.com/gocolly/colly/v2"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 const (
-	base = "https://golang.org/dl"
+	dlBase       = "https://go.dev/dl"
+	manifestPath = "/?mode=json&include=all"
+	pinFile      = ".go-version"
 )
 
+// Release describes a single Go version entry as published in the
+// go.dev/dl JSON manifest.
+type Release struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+	Files   []File `json:"files"`
+}
+
+// File describes a single downloadable artefact of a Release.
+type File struct {
+	Filename string `json:"filename"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Version  string `json:"version"`
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Kind     string `json:"kind"`
+}
+
 // Version build flags
 var (
 	version string
 )
 
 func main() {
-	app := kingpin.New("go-install", "A CLI tool to install/update the latest Go binaries on your machine.")
+	app := kingpin.New("go-install", "A CLI tool to install, update and manage Go runtimes on your machine.")
 
-	root := app.Flag("go-base", "The root path to install the runtime. Go will be installed in `go-base/go`.").
+	root := app.Flag("go-base", "The root path used to store Go runtimes. The active one is available at `go-base/go` (go-base/go.bat on Windows).").
 		Envar("GO_BASE").
 		Short('g').
 		Required().
 		String()
 
-	yes := app.Flag("yes", "Disables pre-installation user confirmation.").
+	ver := app.Flag("version", "Displays the current version of the tool.").Short('v').Bool()
+
+	installCmd := app.Command("install", "Install and activate a Go runtime.").Default()
+	yes := installCmd.Flag("yes", "Disables pre-installation user confirmation.").
 		Short('y').
 		NoEnvar().
 		Bool()
-
-	runtimeVersion := app.Arg("runtime-version", "Go runtime version to install. Leave it empty to install the latest (eg. 1.17.8).").
+	downloadOnly := installCmd.Flag("download-only", "Only downloads and checksum-verifies the archive, without touching go-base.").
+		Bool()
+	out := installCmd.Flag("out", "Destination directory for --download-only.").
+		String()
+	mirror := installCmd.Flag("mirror", "Overrides the https://go.dev/dl base used to discover and download releases.").
+		Envar("GO_DOWNLOAD_MIRROR").
+		String()
+	resume := installCmd.Flag("resume", "Resumes an interrupted download instead of starting over.").
+		Bool()
+	setupEnv := installCmd.Flag("setup-env", "Wires up PATH/GOPATH in your shell profile after a successful install, without prompting.").
+		Bool()
+	noSetupEnv := installCmd.Flag("no-setup-env", "Skips shell profile setup without prompting.").
+		Bool()
+	runtimeVersion := installCmd.Arg("runtime-version", "Go runtime version to install. Leave it empty to install the latest, or to honour a `.go-version` file (eg. 1.17.8).").
 		String()
 
-	ver := app.Flag("version", "Displays the current version of the tool.").Short('v').Bool()
+	listCmd := app.Command("list", "List the Go runtimes installed under go-base.")
+
+	useCmd := app.Command("use", "Activate an already installed Go runtime.")
+	useVersion := useCmd.Arg("runtime-version", "Version to activate (eg. 1.17.8).").Required().String()
+
+	uninstallCmd := app.Command("uninstall", "Remove an installed Go runtime.")
+	uninstallVersion := uninstallCmd.Arg("runtime-version", "Version to remove (eg. 1.17.8).").Required().String()
 
 	log.SetFlags(0)
-	_, err := app.Parse(os.Args[1:])
+	cmd, err := app.Parse(os.Args[1:])
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -61,89 +106,442 @@ func main() {
 		return
 	}
 
-	suffix := fmt.Sprintf("%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
-	goVersion := strings.ToLower(strings.TrimSpace(*runtimeVersion))
-	toInstall := " the latest "
-	if len(goVersion) > 0 && goVersion != "latest" {
-		suffix = fmt.Sprintf("go%s.%s", strings.TrimPrefix(goVersion, "v"), suffix)
-		toInstall = " "
+	switch cmd {
+	case listCmd.FullCommand():
+		err = cmdList(*root)
+	case useCmd.FullCommand():
+		err = cmdUse(*root, *useVersion)
+	case uninstallCmd.FullCommand():
+		err = cmdUninstall(*root, *uninstallVersion)
+	default:
+		err = cmdInstall(installOptions{
+			root:         *root,
+			runtimeVer:   *runtimeVersion,
+			yes:          *yes,
+			downloadOnly: *downloadOnly,
+			outDir:       *out,
+			mirror:       *mirror,
+			resume:       *resume,
+			setupEnv:     *setupEnv,
+			noSetupEnv:   *noSetupEnv,
+		})
 	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func printVersion() {
+	if version == "" {
+		version = "[built from source]"
+	}
+	fmt.Printf("go-install %s", version)
+}
 
-	var url string
-	c := colly.NewCollector()
-	c.MaxDepth = 1
+type installOptions struct {
+	root         string
+	runtimeVer   string
+	yes          bool
+	downloadOnly bool
+	outDir       string
+	mirror       string
+	resume       bool
+	setupEnv     bool
+	noSetupEnv   bool
+}
 
-	c.OnHTML("a[href]", func(e *colly.HTMLElement) {
-		if len(url) > 0 {
-			return
-		}
-		href := e.Attr("href")
-		if strings.Contains(href, suffix) {
-			url = "https://golang.org" + href
+func cmdInstall(opts installOptions) error {
+	goVersion := strings.ToLower(strings.TrimSpace(opts.runtimeVer))
+	if goVersion == "" {
+		pinned, err := pinnedVersion()
+		if err != nil {
+			return err
 		}
-	})
+		goVersion = pinned
+	}
+
+	toInstall := "the latest"
+	if len(goVersion) > 0 && goVersion != "latest" {
+		toInstall = "go" + strings.TrimPrefix(goVersion, "v")
+	}
 
-	log.Printf("Looking for%s%s release on the server.", toInstall, suffix)
-	err = c.Visit(base)
+	base := releaseBase(opts.mirror)
+	if base != dlBase {
+		log.Printf("Warning: using mirror %s for both the release manifest and the archive - checksum verification only proves the archive matches what this mirror published, not what go.dev published.", base)
+	}
+	log.Printf("Looking for %s release for %s/%s on %s.", toInstall, runtime.GOOS, runtime.GOARCH, base)
+	releases, err := fetchReleases(base)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	if len(url) == 0 {
-		log.Fatalf("%s file was not found on the server!", suffix)
+	release, file, err := findFile(releases, goVersion)
+	if err != nil {
+		return err
 	}
+	url := fmt.Sprintf("%s/%s", base, file.Filename)
+	newVersion := strings.TrimPrefix(release.Version, "go")
 
-	newVersion, currentVersion := checkVersions(url)
+	if opts.downloadOnly {
+		return runDownloadOnly(url, file.SHA256, newVersion, opts)
+	}
 
 	msg := fmt.Sprintf("Requested: v%s", newVersion)
-	if currentVersion != "" {
-		msg = fmt.Sprintf("Installed: v%s, ", currentVersion) + msg
+	if active := activeVersion(opts.root); active != "" {
+		msg = fmt.Sprintf("Active: v%s, ", active) + msg
+	}
+
+	if !askForConfirmation(opts.yes, msg+" Would you like to proceed") {
+		return nil
+	}
+
+	tarFile, err := downloadFile(url, os.TempDir(), opts.resume, file.SHA256)
+	if err != nil {
+		return err
 	}
+	defer cleanup(tarFile)
 
-	if !askForConfirmation(*yes, msg+" Would you like to proceed") {
+	if err := install(newVersion, tarFile, opts.root); err != nil {
+		return err
+	}
+	fmt.Println(newVersion)
+
+	if !opts.noSetupEnv {
+		maybeSetupEnv(opts)
+	}
+	return nil
+}
+
+// maybeSetupEnv wires up PATH/GOPATH in the user's shell profile, honouring
+// --setup-env/--yes to skip the extra confirmation prompt.
+func maybeSetupEnv(opts installOptions) {
+	if !opts.setupEnv && !askForConfirmation(opts.yes, "Would you like go-install to wire up PATH/GOPATH in your shell profile") {
 		return
 	}
+	if err := setupShellEnv(opts.root); err != nil {
+		log.Printf("Failed to set up the shell environment: %s", err)
+	}
+}
 
-	log.Printf("Preparing to install v%s", newVersion)
+// setupShellEnv appends PATH/GOPATH exports to the rc file of the user's
+// current shell, mirroring what getgo does after a fresh install.
+func setupShellEnv(root string) error {
+	// activateVersion only creates <go-base>/go on unix; on Windows the
+	// entry point is the <go-base>/go.bat shim, so that's what PATH needs.
+	pathEntry := filepath.Join(activeLink(root), "bin")
+	if runtime.GOOS == "windows" {
+		pathEntry = root
+	}
 
-	tarFile, err := downloadFile(url)
+	home, err := os.UserHomeDir()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
+	rcFile, snippet := shellProfile(home, pathEntry)
+	if rcFile == "" {
+		fmt.Println(snippet)
+		return nil
+	}
+
+	if err := appendIfMissing(rcFile, snippet); err != nil {
+		return err
+	}
+
+	log.Printf("Added PATH/GOPATH exports to %s", rcFile)
+	fmt.Printf("Run `source %s` to pick them up in this shell.\n", rcFile)
+	return nil
+}
+
+// shellProfile returns the rc file to append to and the snippet to append,
+// based on the current shell. pathEntry is the directory to add to PATH. An
+// empty rcFile means the snippet can't be appended automatically and should
+// be printed for the user to run by hand.
+func shellProfile(home, pathEntry string) (rcFile, snippet string) {
+	if runtime.GOOS == "windows" {
+		if os.Getenv("PSModulePath") != "" {
+			rcFile = filepath.Join(home, "Documents", "WindowsPowerShell", "Microsoft.PowerShell_profile.ps1")
+			snippet = fmt.Sprintf("$env:Path += \";%s\"\r\n$env:GOPATH = \"$HOME\\go\"\r\n", pathEntry)
+			return rcFile, snippet
+		}
+		return "", fmt.Sprintf("set PATH=%%PATH%%;%s\r\nset GOPATH=%%USERPROFILE%%\\go\r\n", pathEntry)
+	}
+
+	switch filepath.Base(os.Getenv("SHELL")) {
+	case "fish":
+		rcFile = filepath.Join(home, ".config", "fish", "config.fish")
+		snippet = fmt.Sprintf("set -gx PATH $PATH %s\nset -gx GOPATH $HOME/go\n", pathEntry)
+	case "zsh":
+		rcFile = filepath.Join(home, ".zshrc")
+		snippet = fmt.Sprintf("export PATH=$PATH:%s\nexport GOPATH=$HOME/go\n", pathEntry)
+	default:
+		rcFile = filepath.Join(home, ".bashrc")
+		snippet = fmt.Sprintf("export PATH=$PATH:%s\nexport GOPATH=$HOME/go\n", pathEntry)
+	}
+	return rcFile, snippet
+}
+
+// appendIfMissing appends snippet to rcFile unless it's already there.
+func appendIfMissing(rcFile, snippet string) error {
+	existing, err := os.ReadFile(rcFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if strings.Contains(string(existing), snippet) {
+		return nil
+	}
+
+	f, err := os.OpenFile(rcFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
 	defer func() {
-		cleanup(tarFile)
-		current := getCurrentVersion()
-		fmt.Println(strings.TrimSpace(current))
+		if err := f.Close(); err != nil {
+			log.Printf("Failed to close %s: %s", rcFile, err)
+		}
 	}()
 
-	err = install(newVersion, currentVersion, tarFile, *root)
+	if _, err := f.WriteString("\n" + snippet); err != nil {
+		return err
+	}
+	return nil
+}
+
+// runDownloadOnly fetches and checksum-verifies the archive into opts.outDir
+// without touching opts.root, for staging onto a mirror or an air-gapped box.
+func runDownloadOnly(url, sha256Sum, newVersion string, opts installOptions) error {
+	if opts.outDir == "" {
+		return fmt.Errorf("--out is required with --download-only")
+	}
+
+	msg := fmt.Sprintf("Download v%s into %s", newVersion, opts.outDir)
+	if !askForConfirmation(opts.yes, msg+" Would you like to proceed") {
+		return nil
+	}
+
+	if err := os.MkdirAll(opts.outDir, 0755); err != nil {
+		return err
+	}
+
+	tarFile, err := downloadFile(url, opts.outDir, opts.resume, sha256Sum)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+
+	fmt.Println(tarFile)
+	return nil
 }
 
-func printVersion() {
-	if version == "" {
-		version = "[built from source]"
+// releaseBase returns the base URL used to discover and download releases,
+// honouring --mirror/GO_DOWNLOAD_MIRROR when set.
+func releaseBase(mirror string) string {
+	if mirror = strings.TrimSuffix(strings.TrimSpace(mirror), "/"); mirror != "" {
+		return mirror
 	}
-	fmt.Printf("go-install %s", version)
+	return dlBase
 }
 
-func install(newVersion, currentVersion, downloadedTar, root string) error {
-	if currentVersion != "" {
-		err := removeCurrentVersion(currentVersion, root)
-		if err != nil {
+func cmdList(root string) error {
+	entries, err := os.ReadDir(versionsDir(root))
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("No Go runtimes installed yet.")
+			return nil
+		}
+		return err
+	}
+
+	active := activeVersion(root)
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), "go") {
+			continue
+		}
+		ver := strings.TrimPrefix(e.Name(), "go")
+		marker := "  "
+		if ver == active {
+			marker = "* "
+		}
+		fmt.Printf("%s%s\n", marker, ver)
+	}
+	return nil
+}
+
+func cmdUse(root, ver string) error {
+	return activateVersion(root, normaliseVersion(ver))
+}
+
+func cmdUninstall(root, ver string) error {
+	ver = normaliseVersion(ver)
+	dest := versionDir(root, ver)
+	log.Printf("Removing go%s", ver)
+	if err := os.RemoveAll(dest); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", dest, err)
+	}
+
+	if activeVersion(root) == ver {
+		if err := removeActiveLink(root); err != nil {
+			log.Printf("Failed to remove the active go link: %s", err)
+		}
+		if err := os.Remove(activeVersionFile(root)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove the active version marker: %s", err)
+		}
+	}
+	return nil
+}
+
+func normaliseVersion(ver string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ver)), "v")
+}
+
+// pinnedVersion walks up from the current working directory looking for a
+// .go-version file, mirroring the pinning convention used by tools like
+// nvm and rbenv.
+func pinnedVersion() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, pinFile))
+		if err == nil {
+			return normaliseVersion(string(data)), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+func versionsDir(root string) string {
+	return filepath.Join(root, "versions")
+}
+
+func versionDir(root, ver string) string {
+	return filepath.Join(versionsDir(root), "go"+ver)
+}
+
+func activeLink(root string) string {
+	return filepath.Join(root, "go")
+}
+
+func activeVersionFile(root string) string {
+	return filepath.Join(root, ".active-version")
+}
+
+// activeVersion returns the version currently activated under root, or an
+// empty string when none is.
+func activeVersion(root string) string {
+	data, err := os.ReadFile(activeVersionFile(root))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// activateVersion repoints go-base/go at the requested, already installed
+// version, using a symlink everywhere except Windows, where it writes a
+// small shim batch file instead.
+func activateVersion(root, ver string) error {
+	dest := versionDir(root, ver)
+	if _, err := os.Stat(dest); err != nil {
+		return fmt.Errorf("go%s is not installed under %s", ver, versionsDir(root))
+	}
+
+	link := activeLink(root)
+	if runtime.GOOS == "windows" {
+		if err := writeWindowsShim(link, dest); err != nil {
 			return err
 		}
+	} else {
+		if err := os.RemoveAll(link); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", link, err)
+		}
+		if err := os.Symlink(dest, link); err != nil {
+			return fmt.Errorf("failed to activate go%s: %w", ver, err)
+		}
 	}
+
+	if err := os.WriteFile(activeVersionFile(root), []byte(ver), 0644); err != nil {
+		return fmt.Errorf("failed to record the active version: %w", err)
+	}
+	log.Printf("Activated go%s", ver)
+	return nil
+}
+
+// removeActiveLink removes whatever activateVersion created to expose the
+// active runtime: the go.bat shim on Windows, or the go symlink elsewhere.
+func removeActiveLink(root string) error {
+	link := activeLink(root)
+	if runtime.GOOS == "windows" {
+		if err := os.Remove(link + ".bat"); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.RemoveAll(link)
+}
+
+func writeWindowsShim(link, dest string) error {
+	shimPath := link + ".bat"
+	script := fmt.Sprintf("@echo off\r\n\"%s\" %%*\r\n", filepath.Join(dest, "bin", "go.exe"))
+	if err := os.WriteFile(shimPath, []byte(script), 0755); err != nil {
+		return fmt.Errorf("failed to write shim %s: %w", shimPath, err)
+	}
+	return nil
+}
+
+// install extracts a freshly downloaded archive into go-base/versions/go<ver>
+// and activates it, skipping the extraction step when that version is
+// already installed.
+func install(newVersion, downloadedTar, root string) error {
+	dest := versionDir(root, newVersion)
+	if _, err := os.Stat(dest); err == nil {
+		log.Printf("v%s is already installed, activating it", newVersion)
+		return activateVersion(root, newVersion)
+	}
+
 	log.Printf("Installing v%s runtime", newVersion)
+	vDir := versionsDir(root)
+	if err := os.MkdirAll(vDir, 0755); err != nil {
+		return err
+	}
+
+	staging, err := os.MkdirTemp(vDir, "staging-go"+newVersion+"-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(staging); err != nil {
+			log.Printf("Failed to remove the staging directory %s: %s", staging, err)
+		}
+	}()
+
+	if err := extract(downloadedTar, staging); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(staging, "go"), dest); err != nil {
+		return fmt.Errorf("failed to move the extracted runtime into place: %w", err)
+	}
+
+	return activateVersion(root, newVersion)
+}
 
-	return extract(downloadedTar, root)
+func extract(archiveName, destinationDir string) error {
+	if strings.HasSuffix(archiveName, ".zip") {
+		return extractZip(archiveName, destinationDir)
+	}
+	return extractTarGz(archiveName, destinationDir)
 }
 
 // https://medium.com/learning-the-go-programming-language/working-with-compressed-tar-files-in-go-e6fe9ce4f51d
-func extract(tarName, destinationDir string) (err error) {
+func extractTarGz(tarName, destinationDir string) (err error) {
 	tarFile, err := os.Open(tarName)
 	if err != nil {
 		return err
@@ -216,29 +614,121 @@ func extract(tarName, destinationDir string) (err error) {
 	return nil
 }
 
-func removeCurrentVersion(currentVersion string, root string) error {
-	log.Printf("Removing v%s files", currentVersion)
-	currentPath := path.Join(root, "go")
-	err := os.RemoveAll(currentPath)
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove %s: %w", currentPath, err)
+func extractZip(zipName, destinationDir string) error {
+	zr, err := zip.OpenReader(zipName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := zr.Close(); err != nil {
+			log.Printf("Failed to close the zip reader: %s", err)
+		}
+	}()
+
+	absPath, err := filepath.Abs(destinationDir)
+	if err != nil {
+		return err
+	}
+
+	for _, zf := range zr.File {
+		absFileName := filepath.Join(absPath, zf.Name)
+		if zf.FileInfo().IsDir() {
+			if err := os.MkdirAll(absFileName, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(absFileName), 0755); err != nil {
+			return err
+		}
+
+		rc, err := zf.Open()
+		if err != nil {
+			return err
+		}
+
+		file, err := os.OpenFile(
+			absFileName,
+			os.O_RDWR|os.O_CREATE|os.O_TRUNC,
+			zf.Mode().Perm(),
+		)
+		if err != nil {
+			_ = rc.Close()
+			return err
+		}
+
+		log.Printf("Extracting %s", absFileName)
+		n, cpErr := io.Copy(file, rc)
+		closeErr := file.Close()
+		_ = rc.Close()
+		if closeErr != nil {
+			return closeErr
+		}
+		if cpErr != nil {
+			return cpErr
+		}
+		if uint64(n) != zf.UncompressedSize64 {
+			return fmt.Errorf("file size mismatch. Wrote %d, Wanted %d", n, zf.UncompressedSize64)
+		}
 	}
 	return nil
 }
 
-func checkVersions(url string) (string, string) {
-	current := getCurrentVersion()
-	reg := regexp.MustCompile(`\d+(\.\d+)?(\.\d+)?`)
-	return reg.FindString(url), strings.TrimSpace(reg.FindString(current))
+// fetchReleases downloads and unmarshals the official go.dev/dl JSON manifest,
+// which lists every published release along with the files (and checksums)
+// available for each one.
+func fetchReleases(base string) ([]Release, error) {
+	resp, err := http.Get(base + manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the release manifest: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close the response body: %s", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s while fetching the release manifest", resp.Status)
+	}
+
+	var releases []Release
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to parse the release manifest: %w", err)
+	}
+	return releases, nil
 }
 
-func getCurrentVersion() string {
-	out, err := exec.Command("go", "version").Output()
-	if err != nil {
-		log.Printf("Could not find current installation: %s", err)
-		return ""
+// findFile locates the archive matching the running GOOS/GOARCH for the
+// requested Go version, or the newest stable release when goVersion is empty
+// or "latest".
+func findFile(releases []Release, goVersion string) (Release, File, error) {
+	wantVersion := ""
+	if len(goVersion) > 0 && goVersion != "latest" {
+		wantVersion = "go" + strings.TrimPrefix(goVersion, "v")
 	}
-	return string(out)
+
+	for _, release := range releases {
+		if wantVersion != "" {
+			if release.Version != wantVersion {
+				continue
+			}
+		} else if !release.Stable {
+			continue
+		}
+
+		for _, file := range release.Files {
+			if file.OS == runtime.GOOS && file.Arch == runtime.GOARCH && file.Kind == "archive" {
+				return release, file, nil
+			}
+		}
+
+		if wantVersion != "" {
+			return Release{}, File{}, fmt.Errorf("no %s/%s archive was found for %s", runtime.GOOS, runtime.GOARCH, wantVersion)
+		}
+	}
+	return Release{}, File{}, fmt.Errorf("no %s/%s archive was found on the server", runtime.GOOS, runtime.GOARCH)
 }
 
 func printDownloadPercent(wg *sync.WaitGroup, resp *grab.Response) {
@@ -266,9 +756,13 @@ func printDownloadPercent(wg *sync.WaitGroup, resp *grab.Response) {
 	}
 }
 
-func downloadFile(url string) (string, error) {
+// downloadFile fetches url into destDir and, once the transfer is complete,
+// has grab hash the downloaded file with SHA256 and fail if it doesn't match
+// want. The hash is computed from the file on disk, not while it streams.
+// An empty want skips verification.
+func downloadFile(url, destDir string, resume bool, want string) (string, error) {
 	file := path.Base(url)
-	dest := path.Join(os.TempDir(), file)
+	dest := path.Join(destDir, file)
 	log.Printf("Downloading %s to %s", file, dest)
 	fmt.Println(url)
 	client := grab.NewClient()
@@ -276,12 +770,24 @@ func downloadFile(url string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to initialise the download request: %w", err)
 	}
-	req.NoResume = true
+	req.NoResume = !resume
+
+	if want != "" {
+		sum, err := hex.DecodeString(want)
+		if err != nil {
+			return "", fmt.Errorf("invalid checksum %q: %w", want, err)
+		}
+		req.SetChecksum(sha256.New(), sum, true)
+	}
+
 	resp := client.Do(req)
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 	go printDownloadPercent(wg, resp)
 	if err := resp.Err(); err != nil {
+		if errors.Is(err, grab.ErrBadChecksum) {
+			return "", fmt.Errorf("checksum mismatch for %s: %w", url, err)
+		}
 		return "", err
 	}
 	wg.Wait()
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNormaliseVersion(t *testing.T) {
+	cases := map[string]string{
+		"v1.21.5": "1.21.5",
+		" 1.20.3 ": "1.20.3",
+		"V1.19":    "1.19",
+		"":         "",
+	}
+	for in, want := range cases {
+		if got := normaliseVersion(in); got != want {
+			t.Errorf("normaliseVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFindFile(t *testing.T) {
+	releases := []Release{
+		{
+			Version: "go1.21.5",
+			Stable:  true,
+			Files: []File{
+				{Filename: "go1.21.5." + runtime.GOOS + "-" + runtime.GOARCH + ".tar.gz", OS: runtime.GOOS, Arch: runtime.GOARCH, Kind: "archive", SHA256: "aaa"},
+				{Filename: "go1.21.5.src.tar.gz", OS: "", Arch: "", Kind: "source"},
+			},
+		},
+		{
+			Version: "go1.21.4",
+			Stable:  true,
+			Files: []File{
+				{Filename: "go1.21.4." + runtime.GOOS + "-" + runtime.GOARCH + ".tar.gz", OS: runtime.GOOS, Arch: runtime.GOARCH, Kind: "archive", SHA256: "bbb"},
+			},
+		},
+		{
+			Version: "go1.22rc1",
+			Stable:  false,
+			Files: []File{
+				{Filename: "go1.22rc1." + runtime.GOOS + "-" + runtime.GOARCH + ".tar.gz", OS: runtime.GOOS, Arch: runtime.GOARCH, Kind: "archive", SHA256: "ccc"},
+			},
+		},
+	}
+
+	t.Run("specific version", func(t *testing.T) {
+		_, file, err := findFile(releases, "1.21.4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if file.SHA256 != "bbb" {
+			t.Errorf("got SHA256 %q, want bbb", file.SHA256)
+		}
+	})
+
+	t.Run("empty version falls back to the newest stable release", func(t *testing.T) {
+		release, _, err := findFile(releases, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if release.Version != "go1.21.5" {
+			t.Errorf("got version %q, want go1.21.5", release.Version)
+		}
+	})
+
+	t.Run("latest falls back to the newest stable release", func(t *testing.T) {
+		release, _, err := findFile(releases, "latest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if release.Version != "go1.21.5" {
+			t.Errorf("got version %q, want go1.21.5", release.Version)
+		}
+	})
+
+	t.Run("unstable releases are skipped when no version is requested", func(t *testing.T) {
+		release, _, err := findFile([]Release{releases[2]}, "")
+		if err == nil {
+			t.Fatalf("expected an error, got release %q", release.Version)
+		}
+	})
+
+	t.Run("unknown version returns an error", func(t *testing.T) {
+		if _, _, err := findFile(releases, "9.9.9"); err == nil {
+			t.Error("expected an error for an unknown version")
+		}
+	})
+
+	t.Run("no matching OS/Arch file returns an error", func(t *testing.T) {
+		noMatch := []Release{
+			{
+				Version: "go1.21.5",
+				Stable:  true,
+				Files:   []File{{Filename: "go1.21.5.windows-arm.zip", OS: "plan9", Arch: "mips", Kind: "archive"}},
+			},
+		}
+		if _, _, err := findFile(noMatch, ""); err == nil {
+			t.Error("expected an error when no file matches GOOS/GOARCH")
+		}
+	})
+}
+
+func TestPinnedVersion(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b", "c")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	old, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(old); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if err := os.Chdir(nested); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := pinnedVersion(); err != nil || got != "" {
+		t.Fatalf("pinnedVersion() = %q, %v, want \"\", nil", got, err)
+	}
+
+	pinPath := filepath.Join(root, "a", pinFile)
+	if err := os.WriteFile(pinPath, []byte("v1.21.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := pinnedVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "1.21.5" {
+		t.Errorf("pinnedVersion() = %q, want 1.21.5", got)
+	}
+}
+
+func TestShellProfile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell detection on windows is covered by the PSModulePath branch")
+	}
+
+	home := t.TempDir()
+	goBin := filepath.Join(home, "go", "bin")
+
+	cases := []struct {
+		shell      string
+		wantSuffix string
+	}{
+		{"/bin/bash", ".bashrc"},
+		{"/usr/bin/zsh", ".zshrc"},
+		{"/usr/local/bin/fish", filepath.Join(".config", "fish", "config.fish")},
+		{"", ".bashrc"},
+	}
+
+	for _, c := range cases {
+		t.Setenv("SHELL", c.shell)
+		rcFile, snippet := shellProfile(home, goBin)
+		if !strings.HasSuffix(rcFile, c.wantSuffix) {
+			t.Errorf("SHELL=%q: rcFile = %q, want suffix %q", c.shell, rcFile, c.wantSuffix)
+		}
+		if !strings.Contains(snippet, goBin) {
+			t.Errorf("SHELL=%q: snippet %q does not reference %q", c.shell, snippet, goBin)
+		}
+	}
+}